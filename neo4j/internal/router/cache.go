@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+)
+
+// RoutingTableCache abstracts where a Router keeps its fetched routing
+// tables. The default is an in-memory map, which is all a single process
+// needs. Implementations that back onto shared storage (a file, etcd, ...)
+// let a fleet of short-lived driver processes pointed at the same cluster
+// share one upstream fetch per TTL window instead of each of them hammering
+// the cluster independently.
+//
+// Get returns a nil table and a zero time.Time, with a nil error, when there
+// is no cached entry for database yet; that is not an error condition, it
+// just means the Router should fetch one.
+type RoutingTableCache interface {
+	Get(database string) (*db.RoutingTable, time.Time, error)
+	Put(database string, t *db.RoutingTable, fetchedAt time.Time) error
+}
+
+// memoryRoutingTableCache is the RoutingTableCache used when the Router is
+// not given one explicitly through WithRoutingCache.
+type memoryRoutingTableCache struct {
+	mut     sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	table     *db.RoutingTable
+	fetchedAt time.Time
+}
+
+func newMemoryRoutingTableCache() *memoryRoutingTableCache {
+	return &memoryRoutingTableCache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *memoryRoutingTableCache) Get(database string) (*db.RoutingTable, time.Time, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	e := c.entries[database]
+	return e.table, e.fetchedAt, nil
+}
+
+func (c *memoryRoutingTableCache) Put(database string, t *db.RoutingTable, fetchedAt time.Time) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.entries[database] = cacheEntry{table: t, fetchedAt: fetchedAt}
+	return nil
+}
+
+// Option configures a Router at construction time.
+type Option func(*Router)
+
+// WithRoutingCache replaces the Router's default in-memory routing table
+// cache with cache, typically a shared backend like filecache.Cache or
+// etcdcache.Cache so that several Router instances can agree on a
+// single routing table per database.
+func WithRoutingCache(cache RoutingTableCache) Option {
+	return func(r *Router) {
+		r.cache = cache
+	}
+}
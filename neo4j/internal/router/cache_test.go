@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import "testing"
+
+// cacheBackend names one RoutingTableCache implementation so the tests in
+// this package can be run against all of them without repeating themselves.
+// The file and etcd backends live in their own opt-in subpackages (so that
+// using them doesn't force every driver consumer to pull in fsnotify or the
+// etcd client) and have their own tests there instead.
+type cacheBackend struct {
+	name string
+	new  func(t *testing.T) RoutingTableCache
+}
+
+var cacheBackends = []cacheBackend{
+	{
+		name: "memory",
+		new: func(t *testing.T) RoutingTableCache {
+			return newMemoryRoutingTableCache()
+		},
+	},
+}
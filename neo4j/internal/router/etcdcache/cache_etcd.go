@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package etcdcache is an opt-in router.RoutingTableCache backend that
+// stores routing tables in etcd. It is kept out of the router package itself
+// so that driver consumers who never ask for this cache don't transitively
+// pull in the etcd client and gRPC.
+package etcdcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+)
+
+// cacheValue is what gets marshalled into the etcd value for a key.
+type cacheValue struct {
+	Table     *db.RoutingTable `json:"table"`
+	FetchedAt time.Time        `json:"fetchedAt"`
+}
+
+// Cache is a router.RoutingTableCache backed by etcd v3, meant for a fleet
+// of driver processes sharing a single cluster: the entry for a database
+// carries a lease matching the routing table's own TimeToLive, so etcd
+// itself expires stale entries and the first process to observe the miss
+// pays for the one upstream fetch that every other process then reuses.
+type Cache struct {
+	client      *clientv3.Client
+	clusterName string
+}
+
+// New returns a Cache that stores routing tables in etcd under a key
+// namespaced by clusterName, so that multiple clusters can share the same
+// etcd instance without clashing.
+func New(client *clientv3.Client, clusterName string) *Cache {
+	return &Cache{client: client, clusterName: clusterName}
+}
+
+func (c *Cache) key(database string) string {
+	return fmt.Sprintf("/neo4j/routing/%s/%s", c.clusterName, database)
+}
+
+func (c *Cache) Get(database string) (*db.RoutingTable, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Get(ctx, c.key(database))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(resp.Kvs) == 0 {
+		// No cached entry, or its lease already expired: that is not an
+		// error, the Router is expected to fetch and Put a fresh one.
+		return nil, time.Time{}, nil
+	}
+
+	var v cacheValue
+	if err := json.Unmarshal(resp.Kvs[0].Value, &v); err != nil {
+		return nil, time.Time{}, err
+	}
+	return v.Table, v.FetchedAt, nil
+}
+
+func (c *Cache) Put(database string, t *db.RoutingTable, fetchedAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ttl := t.TimeToLive
+	if ttl <= 0 {
+		ttl = 1
+	}
+	lease, err := c.client.Grant(ctx, int64(ttl))
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(cacheValue{Table: t, FetchedAt: fetchedAt})
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Put(ctx, c.key(database), string(raw), clientv3.WithLease(lease.ID))
+	return err
+}
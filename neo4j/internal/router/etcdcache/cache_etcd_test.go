@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package etcdcache_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+	poolpackage "github.com/neo4j/neo4j-go-driver/neo4j/internal/pool"
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/router"
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/router/etcdcache"
+)
+
+// poolFake is a router.Pool that delegates borrowing to a closure.
+type poolFake struct {
+	borrow func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error)
+}
+
+func (p *poolFake) Borrow(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+	return p.borrow(names, cancel)
+}
+
+func (p *poolFake) Return(c poolpackage.Connection) {}
+
+// connFake is a pool.Connection that hands back a canned routing table.
+type connFake struct {
+	table *db.RoutingTable
+}
+
+func (c *connFake) GetRoutingTable(ctx context.Context, routerContext map[string]string, database string) (*db.RoutingTable, error) {
+	return c.table, nil
+}
+
+func (c *connFake) Close() error { return nil }
+
+// newTestEtcdCache dials the etcd cluster named by NEO4J_TEST_ETCD_ENDPOINTS,
+// skipping the test when it isn't set: a real etcd cluster isn't available in
+// every environment this package's tests run in.
+func newTestEtcdCache(t *testing.T) router.RoutingTableCache {
+	t.Helper()
+	endpoints := os.Getenv("NEO4J_TEST_ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("set NEO4J_TEST_ETCD_ENDPOINTS to run etcd-backed routing cache tests")
+	}
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   strings.Split(endpoints, ","),
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("unable to dial etcd: %s", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return etcdcache.New(client, t.Name())
+}
+
+// Verify that two Router instances sharing one etcd-backed cache only pay
+// for a single upstream fetch per TTL window: the second router should reuse
+// the table the first one already published.
+func TestEtcdCacheDedupesFetchAcrossRouters(t *testing.T) {
+	cache := newTestEtcdCache(t)
+
+	table := &db.RoutingTable{Readers: []string{"rd1"}, Writers: []string{"wr1"}, TimeToLive: 60}
+	fetches := 0
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+			fetches++
+			return &connFake{table: table}, nil
+		},
+	}
+
+	router1 := router.New("router", func() []string { return []string{} }, nil, pool, router.WithRoutingCache(cache))
+	router2 := router.New("router", func() []string { return []string{} }, nil, pool, router.WithRoutingCache(cache))
+
+	if _, err := router1.Readers(""); err != nil {
+		t.Fatalf("router1: %s", err)
+	}
+	if _, err := router2.Readers(""); err != nil {
+		t.Fatalf("router2: %s", err)
+	}
+
+	if fetches != 1 {
+		t.Errorf("expected a single upstream fetch to be shared, got %d", fetches)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/pool"
+)
+
+// poolFake is a Pool that delegates borrowing to a closure, letting tests
+// observe and control exactly how the router tries to reach routers.
+type poolFake struct {
+	borrow func(names []string, cancel context.CancelFunc) (pool.Connection, error)
+}
+
+func (p *poolFake) Borrow(names []string, cancel context.CancelFunc) (pool.Connection, error) {
+	return p.borrow(names, cancel)
+}
+
+func (p *poolFake) Return(c pool.Connection) {}
+
+// connFake is a pool.Connection that hands back a canned routing table.
+type connFake struct {
+	table *db.RoutingTable
+	err   error
+}
+
+func (c *connFake) GetRoutingTable(ctx context.Context, routerContext map[string]string, database string) (*db.RoutingTable, error) {
+	return c.table, c.err
+}
+
+func (c *connFake) Close() error {
+	return nil
+}
+
+// fetchEvent, servedEvent and failoverEvent record one call each into
+// observerFake, in the shape the matching Observer method received it.
+type fetchEvent struct {
+	database         string
+	duration         time.Duration
+	readers, writers int
+	err              error
+}
+
+type servedEvent struct {
+	database  string
+	fromCache bool
+}
+
+type failoverEvent struct {
+	tried    []string
+	finalErr error
+}
+
+// observerFake is an Observer that just records every call it gets, so
+// tests can assert on Router's observable behavior instead of reaching into
+// its internals.
+type observerFake struct {
+	mut      sync.Mutex
+	fetched  []fetchEvent
+	served   []servedEvent
+	failover []failoverEvent
+}
+
+func (o *observerFake) RoutingTableFetched(database string, duration time.Duration, readers, writers int, err error) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	o.fetched = append(o.fetched, fetchEvent{database, duration, readers, writers, err})
+}
+
+func (o *observerFake) RoutingTableServed(database string, fromCache bool) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	o.served = append(o.served, servedEvent{database, fromCache})
+}
+
+func (o *observerFake) RouterFailover(tried []string, finalErr error) {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+	o.failover = append(o.failover, failoverEvent{tried, finalErr})
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package filecache is an opt-in router.RoutingTableCache backend that
+// persists routing tables to a JSON file on disk, using fsnotify to pick up
+// updates from other processes. It is kept out of the router package itself
+// so that driver consumers who never ask for this cache don't transitively
+// pull in fsnotify.
+package filecache
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+)
+
+// cacheEntry is the on-disk representation of a single database's routing
+// table, one JSON object per line of the cache file.
+type cacheEntry struct {
+	Database  string           `json:"database"`
+	Table     *db.RoutingTable `json:"table"`
+	FetchedAt time.Time        `json:"fetchedAt"`
+}
+
+// Cache is a router.RoutingTableCache backed by a single JSON file on disk.
+// It is meant for a handful of sidecar processes on the same host sharing
+// one routing table: a fsnotify watch on the file's directory means a Put
+// from one process is picked up by every other process's next Get, without
+// polling.
+type Cache struct {
+	path string
+
+	mut     sync.RWMutex
+	entries map[string]cacheEntry
+
+	watcher *fsnotify.Watcher
+}
+
+// New returns a Cache that persists routing tables as JSON in the file at
+// path. A missing file is treated as an empty cache; the file is only
+// written by the first Put.
+func New(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: make(map[string]cacheEntry)}
+	if err := c.reload(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	// Watch path's directory rather than path itself: Put replaces path via
+	// os.Rename, which detaches any watch held on the old inode, so watching
+	// the file directly would only ever see the first update.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	c.watcher = watcher
+	go c.watch()
+
+	return c, nil
+}
+
+func (c *Cache) watch() {
+	name := filepath.Base(c.path)
+	for event := range c.watcher.Events {
+		if filepath.Base(event.Name) != name {
+			continue
+		}
+		if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+			c.reload()
+		}
+	}
+}
+
+func (c *Cache) reload() error {
+	raw, err := os.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]cacheEntry)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	for {
+		var e cacheEntry
+		if err := dec.Decode(&e); err != nil {
+			break
+		}
+		entries[e.Database] = e
+	}
+
+	c.mut.Lock()
+	c.entries = entries
+	c.mut.Unlock()
+	return nil
+}
+
+func (c *Cache) Get(database string) (*db.RoutingTable, time.Time, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	e := c.entries[database]
+	return e.Table, e.FetchedAt, nil
+}
+
+func (c *Cache) Put(database string, t *db.RoutingTable, fetchedAt time.Time) error {
+	c.mut.Lock()
+	c.entries[database] = cacheEntry{Database: database, Table: t, FetchedAt: fetchedAt}
+	entries := make([]cacheEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	c.mut.Unlock()
+
+	// Create the temp file next to c.path, not in $TMPDIR: the final rename
+	// below is only atomic (and only guaranteed to succeed at all) when both
+	// live on the same filesystem.
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), "neo4j-routing-cache-*")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// Rename is atomic on the same filesystem, so concurrent readers never
+	// observe a half-written cache file.
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func (c *Cache) Close() error {
+	if c.watcher == nil {
+		return nil
+	}
+	return c.watcher.Close()
+}
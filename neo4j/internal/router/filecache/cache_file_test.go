@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package filecache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+)
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing-cache.json")
+	cache, err := New(path)
+	if err != nil {
+		t.Fatalf("unable to create cache: %s", err)
+	}
+	defer cache.Close()
+
+	table := &db.RoutingTable{Readers: []string{"rd1"}, Writers: []string{"wr1"}, TimeToLive: 1}
+	fetchedAt := time.Now()
+	if err := cache.Put("neo4j", table, fetchedAt); err != nil {
+		t.Fatalf("Put: %s", err)
+	}
+
+	got, gotFetchedAt, err := cache.Get("neo4j")
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if len(got.Readers) != 1 || got.Readers[0] != "rd1" {
+		t.Errorf("wrong readers: %v", got.Readers)
+	}
+	if !gotFetchedAt.Equal(fetchedAt) {
+		t.Errorf("wrong fetchedAt: %v, want %v", gotFetchedAt, fetchedAt)
+	}
+}
+
+// Verify that a second Put replaces the entry written by the first one, even
+// though each Put swaps the cache file in via rename. Get reads back through
+// the same Cache, so the fsnotify watch that other processes rely on is not
+// exercised here.
+func TestSurvivesRepeatedPuts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routing-cache.json")
+	cache, err := New(path)
+	if err != nil {
+		t.Fatalf("unable to create cache: %s", err)
+	}
+	defer cache.Close()
+
+	first := &db.RoutingTable{Readers: []string{"rd1"}, TimeToLive: 1}
+	second := &db.RoutingTable{Readers: []string{"rd2"}, TimeToLive: 1}
+
+	if err := cache.Put("neo4j", first, time.Now()); err != nil {
+		t.Fatalf("first Put: %s", err)
+	}
+	if err := cache.Put("neo4j", second, time.Now()); err != nil {
+		t.Fatalf("second Put: %s", err)
+	}
+
+	got, _, err := cache.Get("neo4j")
+	if err != nil {
+		t.Fatalf("Get: %s", err)
+	}
+	if len(got.Readers) != 1 || got.Readers[0] != "rd2" {
+		t.Errorf("expected the second Put to win, got %v", got.Readers)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import "time"
+
+// Observer receives structured events about a Router's activity. It exists
+// so that callers can wire up metrics, logging or tracing of their own
+// choosing (the bundled prommetrics.Observer is one option) instead of the
+// Router emitting nothing observable beyond its return values.
+type Observer interface {
+	// RoutingTableFetched is called once per attempt to fetch a routing
+	// table from a single router, successful or not.
+	RoutingTableFetched(database string, duration time.Duration, readers, writers int, err error)
+	// RoutingTableServed is called once per Readers/Writers call that
+	// returns a table, saying whether it came from cache or was just
+	// fetched.
+	RoutingTableServed(database string, fromCache bool)
+	// RouterFailover is called when a routing table fetch had to fall
+	// through to backup routers and still didn't succeed, naming every
+	// router that was tried and the error that made the Router give up.
+	RouterFailover(tried []string, finalErr error)
+}
+
+// noopObserver is the Observer a Router uses when none is supplied.
+type noopObserver struct{}
+
+func (noopObserver) RoutingTableFetched(database string, duration time.Duration, readers, writers int, err error) {
+}
+func (noopObserver) RoutingTableServed(database string, fromCache bool) {}
+func (noopObserver) RouterFailover(tried []string, finalErr error)      {}
+
+// WithObserver registers obs to receive events about this Router's activity.
+func WithObserver(obs Observer) Option {
+	return func(r *Router) {
+		r.observer = obs
+	}
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package prommetrics is an opt-in router.Observer that exposes Router
+// activity as Prometheus metrics. It is kept out of the router package
+// itself so that driver consumers who never ask for this observer don't
+// transitively pull in the Prometheus client.
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer is a router.Observer that exposes Router activity as Prometheus
+// metrics: neo4j_routing_fetches_total, neo4j_routing_fetch_duration_seconds
+// and neo4j_routing_failovers_total. Create one with New and share it across
+// every Router in the process via router.WithObserver.
+type Observer struct {
+	fetchesTotal   *prometheus.CounterVec
+	fetchDuration  *prometheus.HistogramVec
+	failoversTotal prometheus.Counter
+}
+
+// New creates an Observer and registers its metrics with registerer.
+func New(registerer prometheus.Registerer) *Observer {
+	o := &Observer{
+		fetchesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "neo4j_routing_fetches_total",
+			Help: "Number of routing table fetches attempted, labelled by database and outcome.",
+		}, []string{"database", "outcome"}),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "neo4j_routing_fetch_duration_seconds",
+			Help: "Duration of routing table fetches, labelled by database.",
+		}, []string{"database"}),
+		failoversTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "neo4j_routing_failovers_total",
+			Help: "Number of times a routing table fetch had to fall through to backup routers and still failed.",
+		}),
+	}
+	registerer.MustRegister(o.fetchesTotal, o.fetchDuration, o.failoversTotal)
+	return o
+}
+
+func (o *Observer) RoutingTableFetched(database string, duration time.Duration, readers, writers int, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	o.fetchesTotal.WithLabelValues(database, outcome).Inc()
+	o.fetchDuration.WithLabelValues(database).Observe(duration.Seconds())
+}
+
+func (o *Observer) RoutingTableServed(database string, fromCache bool) {}
+
+func (o *Observer) RouterFailover(tried []string, finalErr error) {
+	o.failoversTotal.Inc()
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how a Router retries the full [root, backups...]
+// router sequence when none of them could be reached. Backoff is full-jitter:
+// each attempt waits a random duration between zero and the current
+// interval, which then grows by Multiplier up to MaxInterval.
+type RetryPolicy struct {
+	MaxElapsedTime  time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// defaultRetryPolicy mirrors the Neo4j driver spec's default transaction
+// retry budget: keep trying for 30 seconds total.
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxElapsedTime:  30 * time.Second,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     60 * time.Second,
+		Multiplier:      2.0,
+	}
+}
+
+// WithRetryPolicy overrides the default RetryPolicy a Router uses when
+// fetching a routing table fails against every known router.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(r *Router) {
+		r.retryPolicy = policy
+	}
+}
+
+// nextInterval returns the backoff interval to use after interval, grown by
+// policy.Multiplier and capped at policy.MaxInterval.
+func nextInterval(interval time.Duration, policy RetryPolicy) time.Duration {
+	next := time.Duration(float64(interval) * policy.Multiplier)
+	if next > policy.MaxInterval {
+		next = policy.MaxInterval
+	}
+	return next
+}
+
+// jitter returns a random duration in [0, interval], full-jitter style.
+func jitter(interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(interval) + 1))
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+	poolpackage "github.com/neo4j/neo4j-go-driver/neo4j/internal/pool"
+)
+
+// fakeClock lets a test drive backoff deterministically: now() reports a
+// virtual clock that sleep() advances instead of actually blocking.
+type fakeClock struct {
+	n     time.Time
+	slept []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{n: time.Now()}
+}
+
+func (c *fakeClock) now() time.Time { return c.n }
+
+func (c *fakeClock) sleep(ctx context.Context, d time.Duration) {
+	c.slept = append(c.slept, d)
+	c.n = c.n.Add(d)
+}
+
+// (1) total wall time (as tracked by the virtual clock) must respect
+// MaxElapsedTime, give or take the last, possibly oversized, interval.
+// (2) each router must have been retried at least once before giving up.
+func TestRetryRespectsMaxElapsedTimeAndRetriesEachRouter(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.n
+	tried := []string{}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+			tried = append(tried, names...)
+			return nil, errors.New("fail")
+		},
+	}
+	policy := RetryPolicy{
+		MaxElapsedTime:  5 * time.Second,
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     2 * time.Second,
+		Multiplier:      2,
+	}
+	router := New("root", func() []string { return []string{"bup1", "bup2"} }, nil, pool, WithRetryPolicy(policy))
+	router.now = clock.now
+	router.sleep = clock.sleep
+
+	if _, err := router.Readers(defaultDatabase); err == nil {
+		t.Fatal("expected an error, every router fails in this test")
+	}
+
+	if clock.n.Sub(start) > policy.MaxElapsedTime+policy.MaxInterval {
+		t.Errorf("retried past MaxElapsedTime: elapsed %v", clock.n.Sub(start))
+	}
+
+	counts := map[string]int{}
+	for _, name := range tried {
+		counts[name]++
+	}
+	for _, name := range []string{"root", "bup1", "bup2"} {
+		if counts[name] < 2 {
+			t.Errorf("expected %q to be retried at least once, tried %d times", name, counts[name])
+		}
+	}
+}
+
+// (3) a mid-sequence success must short-circuit any remaining backoff: once
+// bup1 succeeds, bup2 should never be tried again.
+func TestRetrySucceedsMidSequence(t *testing.T) {
+	clock := newFakeClock()
+	table := &db.RoutingTable{Readers: []string{"rd1"}, TimeToLive: 1}
+	pass := 0
+	tried := []string{}
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+			name := names[0]
+			tried = append(tried, name)
+			if pass >= 1 && name == "bup1" {
+				return &connFake{table: table}, nil
+			}
+			if name == "bup2" {
+				pass++
+			}
+			return nil, errors.New("fail")
+		},
+	}
+	policy := RetryPolicy{MaxElapsedTime: 10 * time.Second, InitialInterval: time.Second, MaxInterval: time.Second, Multiplier: 1}
+	router := New("root", func() []string { return []string{"bup1", "bup2"} }, nil, pool, WithRetryPolicy(policy))
+	router.now = clock.now
+	router.sleep = clock.sleep
+
+	readers, err := router.Readers(defaultDatabase)
+	if err != nil {
+		t.Fatalf("expected eventual success, got %s", err)
+	}
+	if len(readers) != 1 || readers[0] != "rd1" {
+		t.Fatalf("unexpected readers: %v", readers)
+	}
+	if tried[len(tried)-1] != "bup1" {
+		t.Errorf("expected the sequence to stop right after the success, last tried: %s", tried[len(tried)-1])
+	}
+}
+
+// (4) cancelling ctx mid-backoff must abort the wait immediately instead of
+// sleeping out the rest of the interval.
+func TestRetryAbortsBackoffOnContextCancellation(t *testing.T) {
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+			return nil, errors.New("fail")
+		},
+	}
+	// An interval long enough that the test would time out if cancellation
+	// didn't cut the backoff short.
+	policy := RetryPolicy{MaxElapsedTime: time.Hour, InitialInterval: time.Hour, MaxInterval: time.Hour, Multiplier: 1}
+	router := New("root", func() []string { return []string{} }, nil, pool, WithRetryPolicy(policy))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	if _, err := router.ReadersContext(ctx, defaultDatabase); err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("backoff should have been aborted shortly after cancellation, took %v", elapsed)
+	}
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright (c) 2002-2020 "Neo4j,"
+ * Neo4j Sweden AB [http://neo4j.com]
+ *
+ * This file is part of Neo4j.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *  Unless required by applicable law or agreed to in writing, software
+ *  distributed under the License is distributed on an "AS IS" BASIS,
+ *  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *  See the License for the specific language governing permissions and
+ *  limitations under the License.
+ */
+
+// Package router keeps track of the routing table used to decide which
+// servers in a cluster to use for reading and writing.
+package router
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/db"
+	"github.com/neo4j/neo4j-go-driver/neo4j/internal/pool"
+)
+
+// Pool is the subset of the connection pool that the router needs in order
+// to borrow a connection to one of the routers and use it to fetch a new
+// routing table.
+type Pool interface {
+	Borrow(names []string, cancel context.CancelFunc) (pool.Connection, error)
+	Return(c pool.Connection)
+}
+
+// defaultDatabase is the database name used for back-compat when a caller
+// doesn't care about multi-database routing: it behaves exactly like the
+// single global table this Router used to manage.
+const defaultDatabase = ""
+
+// Router keeps track of the current routing table, per database, and takes
+// care of refreshing entries from one of the cluster routers whenever they
+// expire. Databases are otherwise fully independent of one another: each
+// gets its own fetch, its own TTL and its own backup-router fallback.
+type Router struct {
+	rootRouter    string
+	getRouters    func() []string
+	routerContext map[string]string
+	pool          Pool
+	cache         RoutingTableCache
+	retryPolicy   RetryPolicy
+	observer      Observer
+	now           func() time.Time
+	// sleep waits out a backoff interval. It must return as soon as ctx is
+	// done, even if that's before d has elapsed, so that a cancelled backoff
+	// never leaves a goroutine blocked on a real timer.
+	sleep func(ctx context.Context, d time.Duration)
+
+	fetchMu    sync.RWMutex
+	fetchLocks map[string]*sync.Mutex
+}
+
+// New creates a router that fetches its initial routing table lazily, on
+// first use. rootRouter is the server to ask first, getRouters is called to
+// retrieve a list of backup routers to fall back to when rootRouter can't be
+// reached. By default routing tables are cached in-memory; pass
+// WithRoutingCache to share a cache across Router instances instead.
+func New(rootRouter string, getRouters func() []string, routerContext map[string]string, pool Pool, opts ...Option) *Router {
+	r := &Router{
+		rootRouter:    rootRouter,
+		getRouters:    getRouters,
+		routerContext: routerContext,
+		pool:          pool,
+		cache:         newMemoryRoutingTableCache(),
+		retryPolicy:   defaultRetryPolicy(),
+		observer:      noopObserver{},
+		now:           time.Now,
+		sleep:         sleepUntilDone,
+		fetchLocks:    make(map[string]*sync.Mutex),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// ReadersContext returns the list of servers that can be used for read
+// access to database, refreshing its routing table first if it has expired.
+// ctx is honored all the way down to the connection borrowed to fetch a
+// fresh table: if ctx is cancelled mid-fetch the stale table, if any, is
+// kept as is rather than being wiped out.
+func (r *Router) ReadersContext(ctx context.Context, database string) ([]string, error) {
+	lock := r.fetchLockFor(database)
+	lock.Lock()
+	defer lock.Unlock()
+	table, err := r.assertTable(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	return table.Readers, nil
+}
+
+// WritersContext returns the list of servers that can be used for write
+// access to database. See ReadersContext for the cancellation semantics.
+func (r *Router) WritersContext(ctx context.Context, database string) ([]string, error) {
+	lock := r.fetchLockFor(database)
+	lock.Lock()
+	defer lock.Unlock()
+	table, err := r.assertTable(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	return table.Writers, nil
+}
+
+// Readers is the context-less counterpart of ReadersContext, kept around for
+// callers that have no context of their own to supply. Pass the zero value
+// for database to get the old, single-database behavior.
+func (r *Router) Readers(database string) ([]string, error) {
+	return r.ReadersContext(context.Background(), database)
+}
+
+// Writers is the context-less counterpart of WritersContext, kept around for
+// callers that have no context of their own to supply. Pass the zero value
+// for database to get the old, single-database behavior.
+func (r *Router) Writers(database string) ([]string, error) {
+	return r.WritersContext(context.Background(), database)
+}
+
+// fetchLockFor returns the mutex that serializes fetches for database,
+// creating it on first use. Databases never share a lock, so concurrent
+// access to different databases never contends beyond this map lookup.
+func (r *Router) fetchLockFor(database string) *sync.Mutex {
+	r.fetchMu.RLock()
+	lock, ok := r.fetchLocks[database]
+	r.fetchMu.RUnlock()
+	if ok {
+		return lock
+	}
+
+	r.fetchMu.Lock()
+	defer r.fetchMu.Unlock()
+	if lock, ok = r.fetchLocks[database]; ok {
+		return lock
+	}
+	lock = &sync.Mutex{}
+	r.fetchLocks[database] = lock
+	return lock
+}
+
+// assertTable makes sure the cached routing table for database is populated
+// and not expired, fetching a fresh one through readTable when needed, and
+// returns it. Caller must hold the lock returned by fetchLockFor(database).
+func (r *Router) assertTable(ctx context.Context, database string) (*db.RoutingTable, error) {
+	table, fetchedAt, err := r.cache.Get(database)
+	if err != nil {
+		return nil, err
+	}
+	if table != nil && r.now().Before(fetchedAt.Add(time.Duration(table.TimeToLive)*time.Second)) {
+		r.observer.RoutingTableServed(database, true)
+		return table, nil
+	}
+
+	table, err = r.readTable(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	r.observer.RoutingTableServed(database, false)
+	return table, nil
+}
+
+// readTable repeatedly walks the root router followed by each of the backup
+// routers, returning as soon as one of them yields a routing table for
+// database. A failed pass over the whole sequence is followed by a
+// full-jitter backoff before trying the sequence again, until r.retryPolicy's
+// MaxElapsedTime is spent. On success the cache entry for database is
+// replaced; on failure (including ctx cancellation) the previous entry, if
+// any, is left untouched so that in-flight callers keep seeing a usable (if
+// stale) table rather than nil.
+func (r *Router) readTable(ctx context.Context, database string) (*db.RoutingTable, error) {
+	names := append([]string{r.rootRouter}, r.getRouters()...)
+	start := r.now()
+	interval := r.retryPolicy.InitialInterval
+	tried := make([]string, 0, len(names))
+
+	var lastErr error
+	for {
+		for _, name := range names {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			tried = append(tried, name)
+
+			fetchStart := r.now()
+			table, err := r.fetchTable(ctx, name, database)
+			if err != nil {
+				r.observer.RoutingTableFetched(database, r.now().Sub(fetchStart), 0, 0, err)
+				lastErr = err
+				continue
+			}
+			r.observer.RoutingTableFetched(database, r.now().Sub(fetchStart), len(table.Readers), len(table.Writers), nil)
+
+			if err := r.cache.Put(database, table, r.now()); err != nil {
+				return nil, err
+			}
+			return table, nil
+		}
+
+		if r.now().Sub(start) >= r.retryPolicy.MaxElapsedTime {
+			break
+		}
+		if err := r.backoff(ctx, interval); err != nil {
+			return nil, err
+		}
+		interval = nextInterval(interval, r.retryPolicy)
+	}
+
+	if lastErr == nil {
+		lastErr = errors.New("router: unable to fetch routing table from any known router")
+	}
+	r.observer.RouterFailover(tried, lastErr)
+	return nil, lastErr
+}
+
+// backoff sleeps a full-jitter duration between zero and interval, aborting
+// immediately with ctx's error if ctx is done first.
+func (r *Router) backoff(ctx context.Context, interval time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.sleep(ctx, jitter(interval))
+	return ctx.Err()
+}
+
+// sleepUntilDone is the default sleep implementation: a timer that gets
+// stopped as soon as ctx is done, so cancelling ctx reclaims the waiting
+// goroutine immediately instead of leaving it blocked until d elapses.
+func sleepUntilDone(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// fetchTable borrows a connection to the named router and asks it for
+// database's routing table. cancel is handed to Borrow the same way a bolt
+// read/write deadline is, so the pool can abort a stuck borrow; the same
+// cancellable context is then passed into GetRoutingTable itself, so a
+// caller-supplied deadline also aborts a fetch already in flight instead of
+// only ever being checked before Borrow runs.
+func (r *Router) fetchTable(ctx context.Context, name, database string) (*db.RoutingTable, error) {
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	conn, err := r.pool.Borrow([]string{name}, cancel)
+	if err != nil {
+		return nil, err
+	}
+	defer r.pool.Return(conn)
+
+	return conn.GetRoutingTable(cctx, r.routerContext, database)
+}
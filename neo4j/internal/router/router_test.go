@@ -32,52 +32,98 @@ import (
 )
 
 // Verifies that concurrent access works as expected relying on the race detector to
-// report supicious behavior.
+// report supicious behavior. Run against every RoutingTableCache backend since
+// each has its own locking to get right.
 func TestMultithreading(t *testing.T) {
-	wg := sync.WaitGroup{}
-	wg.Add(2)
+	for _, backend := range cacheBackends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			wg := sync.WaitGroup{}
+			wg.Add(2)
+
+			// Setup a router that needs to read the routing table essentially on every access to
+			// stress threading a bit more.
+			num := 0
+			table := &db.RoutingTable{Readers: []string{"rd1", "rd2"}, Writers: []string{"wr"}, TimeToLive: 1}
+			pool := &poolFake{
+				borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+					num++
+					return &connFake{table: table}, nil
+				},
+			}
+			n := time.Now()
+			router := New("router", func() []string { return []string{} }, nil, pool, WithRoutingCache(backend.new(t)))
+			router.now = func() time.Time {
+				n = n.Add(time.Duration(table.TimeToLive) * time.Second * 2)
+				return n
+			}
+
+			consumer := func() {
+				for i := 0; i < 30; i++ {
+					readers, err := router.Readers(defaultDatabase)
+					if len(readers) != 2 {
+						t.Error("Wrong number of readers")
+					}
+					if err != nil {
+						t.Error(err)
+					}
+					writers, err := router.Writers(defaultDatabase)
+					if len(writers) != 1 {
+						t.Error("Wrong number of writers")
+					}
+					if err != nil {
+						t.Error(err)
+					}
+
+				}
+				wg.Done()
+			}
 
-	// Setup a router that needs to read the routing table essentially on every access to
-	// stress threading a bit more.
-	num := 0
+			go consumer()
+			go consumer()
+
+			wg.Wait()
+		})
+	}
+}
+
+// Verify that hammering several databases concurrently never trips the race
+// detector: each database's fetch is serialized on its own lock, so the only
+// thing contended across databases is the fetchLocks map lookup itself.
+func TestMultithreadingAcrossDatabases(t *testing.T) {
+	databases := []string{"neo4j", "system", "other"}
 	table := &db.RoutingTable{Readers: []string{"rd1", "rd2"}, Writers: []string{"wr"}, TimeToLive: 1}
 	pool := &poolFake{
 		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
-			num++
 			return &connFake{table: table}, nil
 		},
 	}
+	var clockMut sync.Mutex
 	n := time.Now()
 	router := New("router", func() []string { return []string{} }, nil, pool)
 	router.now = func() time.Time {
+		clockMut.Lock()
+		defer clockMut.Unlock()
 		n = n.Add(time.Duration(table.TimeToLive) * time.Second * 2)
 		return n
 	}
 
-	consumer := func() {
-		for i := 0; i < 30; i++ {
-			readers, err := router.Readers()
-			if len(readers) != 2 {
-				t.Error("Wrong number of readers")
-			}
-			if err != nil {
-				t.Error(err)
-			}
-			writers, err := router.Writers()
-			if len(writers) != 1 {
-				t.Error("Wrong number of writers")
-			}
-			if err != nil {
-				t.Error(err)
+	wg := sync.WaitGroup{}
+	wg.Add(len(databases))
+	for _, database := range databases {
+		database := database
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 30; i++ {
+				if _, err := router.Readers(database); err != nil {
+					t.Error(err)
+				}
+				if _, err := router.Writers(database); err != nil {
+					t.Error(err)
+				}
 			}
-
-		}
-		wg.Done()
+		}()
 	}
-
-	go consumer()
-	go consumer()
-
 	wg.Wait()
 }
 
@@ -88,34 +134,53 @@ func assertNum(t *testing.T, x, y int, msg string) {
 	}
 }
 
+// Run against every RoutingTableCache backend: TTL handling must be the same
+// regardless of where the table is actually stored. Assertions go through
+// the Observer, not a private counter, so they double as a check that
+// RoutingTableFetched/RoutingTableServed fire at the right times.
 func TestRespectsTimeToLive(t *testing.T) {
-	numfetch := 0
-	table := &db.RoutingTable{TimeToLive: 1}
-	pool := &poolFake{
-		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
-			numfetch++
-			return &connFake{table: table}, nil
-		},
-	}
-	nzero := time.Now()
-	n := nzero
-	router := New("router", func() []string { return []string{} }, nil, pool)
-	router.now = func() time.Time {
-		return n
-	}
+	for _, backend := range cacheBackends {
+		backend := backend
+		t.Run(backend.name, func(t *testing.T) {
+			table := &db.RoutingTable{TimeToLive: 1}
+			pool := &poolFake{
+				borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+					return &connFake{table: table}, nil
+				},
+			}
+			obs := &observerFake{}
+			nzero := time.Now()
+			n := nzero
+			router := New("router", func() []string { return []string{} }, nil, pool,
+				WithRoutingCache(backend.new(t)), WithObserver(obs))
+			router.now = func() time.Time {
+				return n
+			}
 
-	// First access should trigger initial table read
-	router.Readers()
-	assertNum(t, numfetch, 1, "Should have fetched initial")
+			// First access should trigger initial table read
+			router.Readers(defaultDatabase)
+			assertNum(t, len(obs.fetched), 1, "Should have fetched initial")
+			assertNum(t, len(obs.served), 1, "Should have served initial")
+			if obs.served[0].fromCache {
+				t.Error("Initial access should not be served from cache")
+			}
 
-	// Second access with time set to same should not trigger a read
-	router.Readers()
-	assertNum(t, numfetch, 1, "Should not have have fetched")
+			// Second access with time set to same should not trigger a read
+			router.Readers(defaultDatabase)
+			assertNum(t, len(obs.fetched), 1, "Should not have have fetched")
+			if !obs.served[1].fromCache {
+				t.Error("Second access should be served from cache")
+			}
 
-	// Third access with time passed table due should trigger fetch
-	n = n.Add(2 * time.Second)
-	router.Readers()
-	assertNum(t, numfetch, 2, "Should have have fetched")
+			// Third access with time passed table due should trigger fetch
+			n = n.Add(2 * time.Second)
+			router.Readers(defaultDatabase)
+			assertNum(t, len(obs.fetched), 2, "Should have have fetched")
+			if obs.served[2].fromCache {
+				t.Error("Third access should not be served from cache")
+			}
+		})
+	}
 }
 
 // Verify that when the routing table can not be retrieved from the root router, a callback
@@ -130,10 +195,14 @@ func TestUseGetRoutersHookWhenInitialRouterFails(t *testing.T) {
 	}
 	rootRouter := "rootRouter"
 	backupRouters := []string{"bup1", "bup2"}
-	router := New(rootRouter, func() []string { return backupRouters }, nil, pool)
+	obs := &observerFake{}
+	// A single straight-line pass, no retries: retry behavior has its own
+	// tests in retry_test.go.
+	router := New(rootRouter, func() []string { return backupRouters }, nil, pool,
+		WithRetryPolicy(RetryPolicy{}), WithObserver(obs))
 
 	// Trigger read of routing table
-	router.Readers()
+	router.Readers(defaultDatabase)
 
 	expected := []string{rootRouter}
 	expected = append(expected, backupRouters...)
@@ -141,4 +210,61 @@ func TestUseGetRoutersHookWhenInitialRouterFails(t *testing.T) {
 	if !reflect.DeepEqual(tried, expected) {
 		t.Errorf("Didn't try the expected routers, tried: %#v", tried)
 	}
+
+	if len(obs.failover) != 1 {
+		t.Fatalf("expected one RouterFailover event, got %d", len(obs.failover))
+	}
+	if !reflect.DeepEqual(obs.failover[0].tried, expected) {
+		t.Errorf("RouterFailover reported the wrong routers: %#v", obs.failover[0].tried)
+	}
+	if obs.failover[0].finalErr == nil {
+		t.Error("RouterFailover should carry the final error")
+	}
+}
+
+// Verify that a context cancelled while a refresh is in flight never wipes out
+// the cached routing table: callers either keep seeing the stale table or
+// observe a refreshed one, but never a nil one.
+func TestCancelledFetchKeepsStaleTable(t *testing.T) {
+	first := &db.RoutingTable{Readers: []string{"rd1"}, Writers: []string{"wr1"}, TimeToLive: 1}
+	second := &db.RoutingTable{Readers: []string{"rd2"}, Writers: []string{"wr2"}, TimeToLive: 1}
+	calls := 0
+	pool := &poolFake{
+		borrow: func(names []string, cancel context.CancelFunc) (poolpackage.Connection, error) {
+			calls++
+			if calls == 1 {
+				return &connFake{table: first}, nil
+			}
+			return &connFake{table: second}, nil
+		},
+	}
+	n := time.Now()
+	router := New("router", func() []string { return []string{} }, nil, pool)
+	router.now = func() time.Time { return n }
+
+	readers, err := router.Readers(defaultDatabase)
+	if err != nil || len(readers) != 1 || readers[0] != "rd1" {
+		t.Fatalf("expected initial table, got %v, %v", readers, err)
+	}
+
+	// Expire the table and try to refresh it with an already cancelled context.
+	n = n.Add(2 * time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := router.ReadersContext(ctx, defaultDatabase); err == nil {
+		t.Fatal("expected cancellation error")
+	}
+
+	// The stale table must still be there, and a subsequent call with a live
+	// context should be able to refresh it normally.
+	if cached, _, _ := router.cache.Get(defaultDatabase); cached != first {
+		t.Fatalf("expected the stale table to be kept after a cancelled fetch, got %v", cached)
+	}
+	readers, err = router.ReadersContext(context.Background(), defaultDatabase)
+	if err != nil {
+		t.Fatalf("unexpected error after cancelled fetch: %v", err)
+	}
+	if len(readers) != 1 || readers[0] != "rd2" {
+		t.Fatalf("expected refreshed table, got %v", readers)
+	}
 }
\ No newline at end of file